@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+// targetStatus is the admin endpoint's JSON view of one target.
+type targetStatus struct {
+	Address string `json:"address"`
+	Weight  int    `json:"weight"`
+	Backup  bool   `json:"backup"`
+	Up      bool   `json:"up"`
+}
+
+// listenerStatus is the admin endpoint's JSON view of one listener.
+type listenerStatus struct {
+	ID               string         `json:"id"`
+	Protocol         string         `json:"protocol"`
+	ListenAddr       string         `json:"listenAddr"`
+	Mode             string         `json:"mode"`
+	Targets          []targetStatus `json:"targets"`
+	TruncatedPackets uint64         `json:"truncatedPackets,omitempty"`
+}
+
+// Snapshot returns the current pool state of every managed listener.
+func (m *Manager) Snapshot() []listenerStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	statuses := make([]listenerStatus, 0, len(m.listeners))
+	for _, rl := range m.listeners {
+		targets := make([]targetStatus, 0, len(rl.config.TargetServers))
+		for _, t := range rl.currentTargets() {
+			up := rl.health == nil || rl.health.isUp(t.Address)
+			targets = append(targets, targetStatus{
+				Address: t.Address,
+				Weight:  t.Weight,
+				Backup:  t.Backup,
+				Up:      up,
+			})
+		}
+		statuses = append(statuses, listenerStatus{
+			ID:               rl.config.ID,
+			Protocol:         rl.config.Protocol,
+			ListenAddr:       rl.config.ListenAddr,
+			Mode:             rl.config.Mode,
+			Targets:          targets,
+			TruncatedPackets: rl.truncatedPackets.Load(),
+		})
+	}
+	return statuses
+}
+
+// startAdminServer serves a JSON snapshot of the pool state at /status.
+func startAdminServer(addr string, mgr *Manager) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(mgr.Snapshot())
+	})
+
+	log.Printf("Admin endpoint listening on %s", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		log.Printf("Admin endpoint error: %v", err)
+	}
+}
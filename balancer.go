@@ -0,0 +1,80 @@
+package main
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"net"
+)
+
+// selectTargets applies the listener's load-balancing mode to its current
+// (health-filtered) target set. clientKey identifies the caller, used by
+// the "hash" mode for sticky selection; it is ignored by the other modes.
+func selectTargets(rl *runningListener, clientKey string) []TargetConfig {
+	targets := healthyTargets(rl)
+	if len(targets) == 0 {
+		return nil
+	}
+
+	switch rl.config.Mode {
+	case "roundrobin":
+		idx := rl.rrCounter.Add(1) - 1
+		return []TargetConfig{targets[idx%uint64(len(targets))]}
+	case "random":
+		return []TargetConfig{targets[rand.Intn(len(targets))]}
+	case "hash":
+		idx := hashKey(clientKey) % uint64(len(targets))
+		return []TargetConfig{targets[idx]}
+	case "failover":
+		return []TargetConfig{failoverTarget(targets)}
+	default: // "broadcast", or unset
+		return targets
+	}
+}
+
+// healthyTargets returns the listener's targets with down ones excluded.
+// If health checking isn't enabled, or every target is down, it returns
+// the full set rather than silently black-holing traffic.
+func healthyTargets(rl *runningListener) []TargetConfig {
+	targets := rl.currentTargets()
+	if rl.health == nil {
+		return targets
+	}
+
+	up := make([]TargetConfig, 0, len(targets))
+	for _, t := range targets {
+		if rl.health.isUp(t.Address) {
+			up = append(up, t)
+		}
+	}
+	if len(up) == 0 {
+		return targets
+	}
+	return up
+}
+
+// failoverTarget returns the first non-backup target, or the first backup
+// target if all of them are backups.
+func failoverTarget(targets []TargetConfig) TargetConfig {
+	for _, t := range targets {
+		if !t.Backup {
+			return t
+		}
+	}
+	return targets[0]
+}
+
+func hashKey(key string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return h.Sum64()
+}
+
+// clientHost extracts the host portion of a net.Addr, falling back to the
+// full address string if it can't be split.
+func clientHost(addr net.Addr) string {
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	return host
+}
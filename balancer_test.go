@@ -0,0 +1,105 @@
+package main
+
+import "testing"
+
+func newTestListener(mode string, targets ...TargetConfig) *runningListener {
+	return newRunningListener(ListenerConfig{ID: "t", Mode: mode, TargetServers: targets})
+}
+
+func TestSelectTargetsBroadcast(t *testing.T) {
+	rl := newTestListener("broadcast", TargetConfig{Address: "a"}, TargetConfig{Address: "b"})
+	got := selectTargets(rl, "client")
+	if len(got) != 2 {
+		t.Fatalf("broadcast mode returned %d targets, want 2: %+v", len(got), got)
+	}
+}
+
+func TestSelectTargetsRoundRobin(t *testing.T) {
+	rl := newTestListener("roundrobin", TargetConfig{Address: "a"}, TargetConfig{Address: "b"})
+
+	seen := make([]string, 4)
+	for i := range seen {
+		got := selectTargets(rl, "client")
+		if len(got) != 1 {
+			t.Fatalf("roundrobin mode returned %d targets, want 1", len(got))
+		}
+		seen[i] = got[0].Address
+	}
+
+	want := []string{"a", "b", "a", "b"}
+	for i := range want {
+		if seen[i] != want[i] {
+			t.Fatalf("roundrobin sequence = %v, want %v", seen, want)
+		}
+	}
+}
+
+func TestSelectTargetsHashIsSticky(t *testing.T) {
+	rl := newTestListener("hash", TargetConfig{Address: "a"}, TargetConfig{Address: "b"}, TargetConfig{Address: "c"})
+
+	first := selectTargets(rl, "10.0.0.5")[0].Address
+	for i := 0; i < 5; i++ {
+		got := selectTargets(rl, "10.0.0.5")[0].Address
+		if got != first {
+			t.Fatalf("hash mode picked %q then %q for the same client key", first, got)
+		}
+	}
+
+	other := selectTargets(rl, "10.0.0.6")
+	if len(other) != 1 {
+		t.Fatalf("hash mode returned %d targets, want 1", len(other))
+	}
+}
+
+func TestSelectTargetsFailoverPrefersNonBackup(t *testing.T) {
+	rl := newTestListener("failover",
+		TargetConfig{Address: "backup", Backup: true},
+		TargetConfig{Address: "primary"},
+	)
+
+	got := selectTargets(rl, "client")
+	if len(got) != 1 || got[0].Address != "primary" {
+		t.Fatalf("failover mode selected %+v, want primary", got)
+	}
+}
+
+func TestSelectTargetsFailoverFallsBackToBackup(t *testing.T) {
+	rl := newTestListener("failover", TargetConfig{Address: "backup", Backup: true})
+
+	got := selectTargets(rl, "client")
+	if len(got) != 1 || got[0].Address != "backup" {
+		t.Fatalf("failover mode with only a backup target selected %+v, want backup", got)
+	}
+}
+
+func TestHealthyTargetsExcludesDown(t *testing.T) {
+	rl := newTestListener("broadcast", TargetConfig{Address: "up"}, TargetConfig{Address: "down"})
+	rl.health = newHealthTracker(rl.config.TargetServers)
+	rl.health.record("down", false, 1)
+
+	got := healthyTargets(rl)
+	if len(got) != 1 || got[0].Address != "up" {
+		t.Fatalf("healthyTargets = %+v, want only \"up\"", got)
+	}
+}
+
+func TestHealthyTargetsFailsOpenWhenAllDown(t *testing.T) {
+	rl := newTestListener("broadcast", TargetConfig{Address: "a"}, TargetConfig{Address: "b"})
+	rl.health = newHealthTracker(rl.config.TargetServers)
+	rl.health.record("a", false, 1)
+	rl.health.record("b", false, 1)
+
+	got := healthyTargets(rl)
+	if len(got) != 2 {
+		t.Fatalf("healthyTargets with everything down = %+v, want the full set (fail open)", got)
+	}
+}
+
+func TestHashKeyIsDeterministic(t *testing.T) {
+	if hashKey("same") != hashKey("same") {
+		t.Fatal("hashKey is not deterministic for the same input")
+	}
+	if hashKey("a") == hashKey("b") {
+		t.Fatal("hashKey collided for distinct trivial inputs")
+	}
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TargetConfig describes a single upstream target. It can be written in
+// config files either as a bare address string ("127.0.0.1:9000") or as a
+// full object with a weight and/or backup flag, e.g.:
+//
+//	targetServers:
+//	  - address: 10.0.0.1:9000
+//	    weight: 5
+//	  - address: 10.0.0.2:9000
+//	    backup: true
+type TargetConfig struct {
+	Address string `json:"address" yaml:"address"`
+	// Weight influences selection in weighted load-balancing modes.
+	// A zero value is treated as 1 (equal weighting).
+	Weight int `json:"weight,omitempty" yaml:"weight,omitempty"`
+	// Backup marks the target as only eligible for selection once all
+	// non-backup targets are unavailable.
+	Backup bool `json:"backup,omitempty" yaml:"backup,omitempty"`
+}
+
+// UnmarshalJSON allows a TargetConfig to be written as either a plain
+// address string or a full object.
+func (t *TargetConfig) UnmarshalJSON(data []byte) error {
+	var addr string
+	if err := json.Unmarshal(data, &addr); err == nil {
+		t.Address = addr
+		return nil
+	}
+
+	type plain TargetConfig
+	var p plain
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	*t = TargetConfig(p)
+	return nil
+}
+
+// UnmarshalYAML allows a TargetConfig to be written as either a plain
+// address string or a full mapping.
+func (t *TargetConfig) UnmarshalYAML(value *yaml.Node) error {
+	var addr string
+	if err := value.Decode(&addr); err == nil {
+		t.Address = addr
+		return nil
+	}
+
+	type plain TargetConfig
+	var p plain
+	if err := value.Decode(&p); err != nil {
+		return err
+	}
+	*t = TargetConfig(p)
+	return nil
+}
+
+// ListenerConfig represents a single listener's configuration.
+type ListenerConfig struct {
+	// ID uniquely identifies the listener across config reloads so that
+	// running listeners can be matched up with their replacement
+	// configuration. If left blank, one is derived from the listen
+	// address.
+	ID       string `json:"id,omitempty" yaml:"id,omitempty"`
+	Protocol string `json:"protocol" yaml:"protocol"`
+
+	// Mode selects how targets are chosen for each connection/packet:
+	// "broadcast" (default, fans out to every target), "roundrobin",
+	// "random", "hash" (consistent hashing on client IP, useful for
+	// sticky UDP sessions), or "failover" (first healthy non-backup
+	// target, falling back to backups).
+	Mode string `json:"mode,omitempty" yaml:"mode,omitempty"`
+
+	// HealthCheckInterval enables active health checking when set, as a
+	// duration string like "5s". Unhealthy targets are excluded from
+	// selection by every mode above.
+	HealthCheckInterval string `json:"healthCheckInterval,omitempty" yaml:"healthCheckInterval,omitempty"`
+
+	// HealthCheckFailureThreshold is how many consecutive failed probes
+	// mark a target down. Defaults to defaultHealthCheckFailureThreshold.
+	HealthCheckFailureThreshold int `json:"healthCheckFailureThreshold,omitempty" yaml:"healthCheckFailureThreshold,omitempty"`
+
+	// ListenAddr is a single address, e.g. ":5000". Mutually exclusive
+	// with ListenAddrRange.
+	ListenAddr string `json:"listenAddr,omitempty" yaml:"listenAddr,omitempty"`
+
+	// ListenAddrRange is a port-range address such as ":5000-5100". It
+	// expands into one listener per port, each inheriting the rest of
+	// this configuration.
+	ListenAddrRange string `json:"listenAddrRange,omitempty" yaml:"listenAddrRange,omitempty"`
+
+	// Framed enables length-prefixed framing for TCP fan-out to multiple
+	// targets, so that discrete messages rather than arbitrary stream
+	// chunks are forwarded. Ignored for single-target listeners, which
+	// are always proxied as a raw bidirectional stream.
+	Framed bool `json:"framed,omitempty" yaml:"framed,omitempty"`
+
+	// UDPIdleTimeout is how long a UDP client session (and its per-target
+	// upstream sockets) is kept alive without traffic before being
+	// evicted, as a duration string like "30s". Defaults to
+	// defaultUDPIdleTimeout when empty or invalid.
+	UDPIdleTimeout string `json:"udpIdleTimeout,omitempty" yaml:"udpIdleTimeout,omitempty"`
+
+	// Network overrides the listen network for UDP listeners: "udp"
+	// (default, dual-stack), "udp4", or "udp6". Useful to pin a listener
+	// to IPv6 link-local addressing.
+	Network string `json:"network,omitempty" yaml:"network,omitempty"`
+
+	// Interface restricts the listener to a specific network interface
+	// by name (e.g. "eth0"). Required when MulticastGroup is set, and
+	// also useful for binding to an IPv6 link-local address, which is
+	// only unambiguous together with a zone/interface.
+	Interface string `json:"interface,omitempty" yaml:"interface,omitempty"`
+
+	// MulticastGroup, when set, makes a UDP listener join this multicast
+	// group (e.g. "239.0.0.1" or "ff02::1") on Interface, listening on
+	// the port from ListenAddr.
+	MulticastGroup string `json:"multicastGroup,omitempty" yaml:"multicastGroup,omitempty"`
+
+	// UDPBufferSize is the size, in bytes, of the buffer used to read
+	// each UDP datagram. Datagrams larger than this are truncated.
+	// Defaults to defaultUDPBufferSize (65535, the largest possible UDP
+	// payload) when zero.
+	UDPBufferSize int `json:"udpBufferSize,omitempty" yaml:"udpBufferSize,omitempty"`
+
+	TargetServers []TargetConfig `json:"targetServers" yaml:"targetServers"`
+}
+
+// AppConfig represents the overall application configuration.
+type AppConfig struct {
+	Listeners []ListenerConfig `json:"listeners" yaml:"listeners"`
+
+	// AdminAddr, when set, serves a small JSON status endpoint (current
+	// listener/target pool state) on this address, e.g. "127.0.0.1:9090".
+	AdminAddr string `json:"adminAddr,omitempty" yaml:"adminAddr,omitempty"`
+}
+
+// loadConfig reads the configuration file and parses it into AppConfig.
+// Both YAML (.yaml, .yml) and JSON (anything else, including .json) are
+// supported, selected by file extension.
+func loadConfig(filename string) (*AppConfig, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var config AppConfig
+	switch ext := strings.ToLower(filepath.Ext(filename)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &config)
+	default:
+		err = json.Unmarshal(data, &config)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", filename, err)
+	}
+
+	if err := expandListeners(&config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// expandListeners resolves ListenAddrRange entries into one concrete
+// ListenerConfig per port and assigns an ID to every listener that
+// doesn't already have one.
+func expandListeners(config *AppConfig) error {
+	var expanded []ListenerConfig
+
+	for _, l := range config.Listeners {
+		if l.ListenAddrRange == "" {
+			if l.ID == "" {
+				l.ID = l.Protocol + ":" + l.ListenAddr
+			}
+			expanded = append(expanded, l)
+			continue
+		}
+
+		addrs, err := expandPortRange(l.ListenAddrRange)
+		if err != nil {
+			return fmt.Errorf("listener %s: %w", l.ListenAddrRange, err)
+		}
+
+		for _, addr := range addrs {
+			copyOf := l
+			copyOf.ListenAddr = addr
+			copyOf.ListenAddrRange = ""
+			if l.ID == "" {
+				copyOf.ID = l.Protocol + ":" + addr
+			} else {
+				copyOf.ID = fmt.Sprintf("%s:%s", l.ID, addr)
+			}
+			expanded = append(expanded, copyOf)
+		}
+	}
+
+	config.Listeners = expanded
+	return nil
+}
+
+// expandPortRange expands an address of the form "host:start-end" (the
+// host portion may be empty, as in ":5000-5100") into the list of
+// concrete "host:port" addresses it covers.
+func expandPortRange(addr string) ([]string, error) {
+	host, portRange, err := splitHostPortRange(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.SplitN(portRange, "-", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid port range %q, expected START-END", portRange)
+	}
+
+	start, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid range start %q: %w", parts[0], err)
+	}
+	end, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid range end %q: %w", parts[1], err)
+	}
+	if end < start {
+		return nil, fmt.Errorf("port range end %d is before start %d", end, start)
+	}
+
+	addrs := make([]string, 0, end-start+1)
+	for port := start; port <= end; port++ {
+		addrs = append(addrs, fmt.Sprintf("%s:%d", host, port))
+	}
+	return addrs, nil
+}
+
+// splitHostPortRange splits "host:start-end" into its host and
+// "start-end" components, tolerating an empty host (":5000-5100").
+func splitHostPortRange(addr string) (host, portRange string, err error) {
+	idx := strings.LastIndex(addr, ":")
+	if idx == -1 {
+		return "", "", fmt.Errorf("invalid listenAddrRange %q, expected HOST:START-END", addr)
+	}
+	return addr[:idx], addr[idx+1:], nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestExpandPortRange(t *testing.T) {
+	tests := []struct {
+		addr    string
+		want    []string
+		wantErr bool
+	}{
+		{addr: ":5000-5002", want: []string{":5000", ":5001", ":5002"}},
+		{addr: "127.0.0.1:9000-9000", want: []string{"127.0.0.1:9000"}},
+		{addr: ":5100-5000", wantErr: true},
+		{addr: ":abc-5000", wantErr: true},
+		{addr: "noport", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := expandPortRange(tt.addr)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("expandPortRange(%q): expected error, got %v", tt.addr, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("expandPortRange(%q): unexpected error: %v", tt.addr, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("expandPortRange(%q) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestTargetConfigUnmarshalJSON(t *testing.T) {
+	var bare TargetConfig
+	if err := json.Unmarshal([]byte(`"10.0.0.1:9000"`), &bare); err != nil {
+		t.Fatalf("bare string: %v", err)
+	}
+	if bare.Address != "10.0.0.1:9000" || bare.Weight != 0 || bare.Backup {
+		t.Errorf("bare string decoded as %+v", bare)
+	}
+
+	var full TargetConfig
+	if err := json.Unmarshal([]byte(`{"address":"10.0.0.2:9000","weight":5,"backup":true}`), &full); err != nil {
+		t.Fatalf("full object: %v", err)
+	}
+	want := TargetConfig{Address: "10.0.0.2:9000", Weight: 5, Backup: true}
+	if full != want {
+		t.Errorf("full object decoded as %+v, want %+v", full, want)
+	}
+}
+
+func TestTargetConfigUnmarshalYAML(t *testing.T) {
+	var bare TargetConfig
+	if err := yaml.Unmarshal([]byte(`10.0.0.1:9000`), &bare); err != nil {
+		t.Fatalf("bare string: %v", err)
+	}
+	if bare.Address != "10.0.0.1:9000" {
+		t.Errorf("bare string decoded as %+v", bare)
+	}
+
+	var full TargetConfig
+	src := "address: 10.0.0.2:9000\nweight: 5\nbackup: true\n"
+	if err := yaml.Unmarshal([]byte(src), &full); err != nil {
+		t.Fatalf("full mapping: %v", err)
+	}
+	want := TargetConfig{Address: "10.0.0.2:9000", Weight: 5, Backup: true}
+	if full != want {
+		t.Errorf("full mapping decoded as %+v, want %+v", full, want)
+	}
+}
+
+func TestExpandListeners(t *testing.T) {
+	cfg := &AppConfig{
+		Listeners: []ListenerConfig{
+			{Protocol: "udp", ListenAddrRange: ":6000-6001"},
+			{Protocol: "tcp", ListenAddr: ":7000"},
+		},
+	}
+
+	if err := expandListeners(cfg); err != nil {
+		t.Fatalf("expandListeners: %v", err)
+	}
+
+	if len(cfg.Listeners) != 3 {
+		t.Fatalf("got %d listeners, want 3: %+v", len(cfg.Listeners), cfg.Listeners)
+	}
+
+	ids := make(map[string]bool)
+	for _, l := range cfg.Listeners {
+		if l.ID == "" {
+			t.Errorf("listener %+v has empty ID", l)
+		}
+		if ids[l.ID] {
+			t.Errorf("duplicate listener ID %q", l.ID)
+		}
+		ids[l.ID] = true
+	}
+}
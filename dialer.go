@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/proxy"
+)
+
+// Dialer establishes a connection to one upstream target. It is used by
+// the TCP forwarders (tcpPool.get); UDP listeners dial targets directly
+// and don't go through a Dialer. Target addresses can select a
+// non-default dialer by scheme, e.g.:
+//
+//	socks5://127.0.0.1:9050/example.onion:80
+//	connect://proxy.internal:3128/backend:8080
+//	tls://backend:8443?sni=api.example.com
+//
+// A bare "host:port" address dials directly over plain TCP.
+type Dialer interface {
+	Dial() (net.Conn, error)
+}
+
+// newDialer parses a target address and returns the Dialer it selects.
+func newDialer(address string) (Dialer, error) {
+	if !strings.Contains(address, "://") {
+		return directDialer{address: address}, nil
+	}
+
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target address %q: %w", address, err)
+	}
+
+	switch u.Scheme {
+	case "tls":
+		return tlsDialer{address: u.Host, serverName: u.Query().Get("sni")}, nil
+	case "socks5":
+		target := strings.TrimPrefix(u.Path, "/")
+		if target == "" {
+			return nil, fmt.Errorf("socks5 target %q missing /host:port path", address)
+		}
+		return socks5Dialer{proxyAddr: u.Host, targetAddr: target}, nil
+	case "connect", "http":
+		target := strings.TrimPrefix(u.Path, "/")
+		if target == "" {
+			return nil, fmt.Errorf("http connect target %q missing /host:port path", address)
+		}
+		return httpConnectDialer{proxyAddr: u.Host, targetAddr: target}, nil
+	default:
+		return nil, fmt.Errorf("unsupported target scheme %q in %q", u.Scheme, address)
+	}
+}
+
+// directDialer dials the target directly over plain TCP.
+type directDialer struct {
+	address string
+}
+
+func (d directDialer) Dial() (net.Conn, error) {
+	return net.Dial("tcp", d.address)
+}
+
+// tlsDialer dials the target directly and terminates TLS on the resulting
+// connection, optionally sending a specific SNI servername.
+type tlsDialer struct {
+	address    string
+	serverName string
+}
+
+func (d tlsDialer) Dial() (net.Conn, error) {
+	return tls.Dial("tcp", d.address, &tls.Config{ServerName: d.serverName})
+}
+
+// socks5Dialer reaches the target by egressing through a SOCKS5 proxy,
+// e.g. a local Tor client.
+type socks5Dialer struct {
+	proxyAddr  string
+	targetAddr string
+}
+
+func (d socks5Dialer) Dial() (net.Conn, error) {
+	dialer, err := proxy.SOCKS5("tcp", d.proxyAddr, nil, proxy.Direct)
+	if err != nil {
+		return nil, fmt.Errorf("configuring socks5 proxy %s: %w", d.proxyAddr, err)
+	}
+	return dialer.Dial("tcp", d.targetAddr)
+}
+
+// httpConnectDialer reaches the target by issuing an HTTP CONNECT through
+// a forward proxy.
+type httpConnectDialer struct {
+	proxyAddr  string
+	targetAddr string
+}
+
+func (d httpConnectDialer) Dial() (net.Conn, error) {
+	conn, err := net.Dial("tcp", d.proxyAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: d.targetAddr},
+		Host:   d.targetAddr,
+		Header: make(http.Header),
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("CONNECT %s via %s: %s", d.targetAddr, d.proxyAddr, resp.Status)
+	}
+
+	// br may have buffered bytes of the tunneled stream beyond the CONNECT
+	// response headers; read through it rather than the raw conn so none
+	// of that is lost.
+	return &bufferedConn{Conn: conn, r: br}, nil
+}
+
+// bufferedConn is a net.Conn whose reads are served from a bufio.Reader
+// that may already hold buffered bytes read past some earlier framing
+// (e.g. an HTTP CONNECT response).
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (b *bufferedConn) Read(p []byte) (int, error) {
+	return b.r.Read(p)
+}
@@ -0,0 +1,71 @@
+package main
+
+import "testing"
+
+func TestNewDialerDirect(t *testing.T) {
+	d, err := newDialer("127.0.0.1:9000")
+	if err != nil {
+		t.Fatalf("newDialer: %v", err)
+	}
+	dd, ok := d.(directDialer)
+	if !ok {
+		t.Fatalf("got %T, want directDialer", d)
+	}
+	if dd.address != "127.0.0.1:9000" {
+		t.Errorf("address = %q", dd.address)
+	}
+}
+
+func TestNewDialerTLS(t *testing.T) {
+	d, err := newDialer("tls://backend:8443?sni=api.example.com")
+	if err != nil {
+		t.Fatalf("newDialer: %v", err)
+	}
+	td, ok := d.(tlsDialer)
+	if !ok {
+		t.Fatalf("got %T, want tlsDialer", d)
+	}
+	if td.address != "backend:8443" || td.serverName != "api.example.com" {
+		t.Errorf("got %+v", td)
+	}
+}
+
+func TestNewDialerSOCKS5(t *testing.T) {
+	d, err := newDialer("socks5://127.0.0.1:9050/example.onion:80")
+	if err != nil {
+		t.Fatalf("newDialer: %v", err)
+	}
+	sd, ok := d.(socks5Dialer)
+	if !ok {
+		t.Fatalf("got %T, want socks5Dialer", d)
+	}
+	if sd.proxyAddr != "127.0.0.1:9050" || sd.targetAddr != "example.onion:80" {
+		t.Errorf("got %+v", sd)
+	}
+}
+
+func TestNewDialerSOCKS5MissingTarget(t *testing.T) {
+	if _, err := newDialer("socks5://127.0.0.1:9050"); err == nil {
+		t.Fatal("expected error for socks5 address with no target path")
+	}
+}
+
+func TestNewDialerHTTPConnect(t *testing.T) {
+	d, err := newDialer("connect://proxy.internal:3128/backend:8080")
+	if err != nil {
+		t.Fatalf("newDialer: %v", err)
+	}
+	hd, ok := d.(httpConnectDialer)
+	if !ok {
+		t.Fatalf("got %T, want httpConnectDialer", d)
+	}
+	if hd.proxyAddr != "proxy.internal:3128" || hd.targetAddr != "backend:8080" {
+		t.Errorf("got %+v", hd)
+	}
+}
+
+func TestNewDialerUnsupportedScheme(t *testing.T) {
+	if _, err := newDialer("ftp://example.com/foo"); err == nil {
+		t.Fatal("expected error for unsupported scheme")
+	}
+}
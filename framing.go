@@ -0,0 +1,41 @@
+package main
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// maxFrameSize bounds a single length-prefixed message to guard against a
+// corrupt or hostile length prefix forcing an enormous allocation.
+const maxFrameSize = 16 * 1024 * 1024
+
+// readFrame reads one length-prefixed message: a 4-byte big-endian length
+// followed by that many bytes of payload.
+func readFrame(r io.Reader) ([]byte, error) {
+	var lenBuf [4]byte
+	if _, err := io.ReadFull(r, lenBuf[:]); err != nil {
+		return nil, err
+	}
+
+	n := binary.BigEndian.Uint32(lenBuf[:])
+	if n > maxFrameSize {
+		return nil, io.ErrShortBuffer
+	}
+
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// writeFrame writes data as one length-prefixed message.
+func writeFrame(w io.Writer, data []byte) error {
+	var lenBuf [4]byte
+	binary.BigEndian.PutUint32(lenBuf[:], uint32(len(data)))
+	if _, err := w.Write(lenBuf[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
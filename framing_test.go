@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestWriteReadFrameRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	messages := [][]byte{[]byte("hello"), []byte(""), []byte("a longer framed message")}
+
+	for _, m := range messages {
+		if err := writeFrame(&buf, m); err != nil {
+			t.Fatalf("writeFrame(%q): %v", m, err)
+		}
+	}
+
+	for _, want := range messages {
+		got, err := readFrame(&buf)
+		if err != nil {
+			t.Fatalf("readFrame: %v", err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("readFrame = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestReadFrameRejectsOversizedLength(t *testing.T) {
+	var buf bytes.Buffer
+	// A length prefix that claims a payload far bigger than maxFrameSize.
+	buf.Write([]byte{0x7F, 0xFF, 0xFF, 0xFF})
+
+	if _, err := readFrame(&buf); err != io.ErrShortBuffer {
+		t.Fatalf("readFrame with oversized length = %v, want io.ErrShortBuffer", err)
+	}
+}
+
+func TestReadFrameShortInput(t *testing.T) {
+	buf := bytes.NewBuffer([]byte{0, 0, 0})
+	if _, err := readFrame(buf); err == nil {
+		t.Fatal("readFrame with truncated length prefix: expected error, got nil")
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"log"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	defaultHealthCheckInterval         = 10 * time.Second
+	defaultHealthCheckFailureThreshold = 3
+	healthCheckTimeout                 = 2 * time.Second
+)
+
+// healthTracker records the up/down state of each target, derived from
+// consecutive probe results.
+type healthTracker struct {
+	mu        sync.RWMutex
+	up        map[string]bool
+	failCount map[string]int
+}
+
+func newHealthTracker(targets []TargetConfig) *healthTracker {
+	h := &healthTracker{
+		up:        make(map[string]bool, len(targets)),
+		failCount: make(map[string]int, len(targets)),
+	}
+	for _, t := range targets {
+		h.up[t.Address] = true
+	}
+	return h
+}
+
+func (h *healthTracker) isUp(address string) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	up, known := h.up[address]
+	return !known || up
+}
+
+// record applies one probe result, marking the target down once its
+// consecutive-failure count reaches threshold, and up again on success.
+func (h *healthTracker) record(address string, ok bool, threshold int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if ok {
+		h.failCount[address] = 0
+		h.up[address] = true
+		return
+	}
+
+	h.failCount[address]++
+	if h.failCount[address] >= threshold {
+		if h.up[address] {
+			log.Printf("Target %s marked down after %d failed probes", address, h.failCount[address])
+		}
+		h.up[address] = false
+	}
+}
+
+// runHealthChecks periodically probes every target configured on rl until
+// rl.stop is closed.
+func runHealthChecks(rl *runningListener, interval time.Duration, threshold int) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-rl.stop:
+			return
+		case <-ticker.C:
+			for _, target := range rl.currentTargets() {
+				go func(target TargetConfig) {
+					ok := probeTarget(rl.config.Protocol, target.Address)
+					rl.health.record(target.Address, ok, threshold)
+				}(target)
+			}
+		}
+	}
+}
+
+// probeTarget runs a single liveness probe against address: a TCP dial for
+// "tcp" listeners, or a UDP echo (send a probe byte, wait for any reply)
+// for "udp" listeners.
+func probeTarget(protocol, address string) bool {
+	if protocol == "tcp" {
+		conn, err := net.DialTimeout("tcp", address, healthCheckTimeout)
+		if err != nil {
+			return false
+		}
+		conn.Close()
+		return true
+	}
+
+	conn, err := net.DialTimeout("udp", address, healthCheckTimeout)
+	if err != nil {
+		return false
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte{0}); err != nil {
+		return false
+	}
+	conn.SetReadDeadline(time.Now().Add(healthCheckTimeout))
+	buf := make([]byte, 1)
+	_, err = conn.Read(buf)
+	return err == nil
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// runningListener tracks a single active listener so that a config reload
+// can update its targets or tear it down without disturbing the others.
+type runningListener struct {
+	config  ListenerConfig
+	targets atomic.Value // []TargetConfig
+	stop    chan struct{}
+
+	// tcpPool holds idle upstream connections for reuse. Only used by
+	// TCP listeners.
+	tcpPool *tcpPool
+
+	// udpSessions tracks active client sessions, keyed by client address
+	// string. Only used by UDP listeners.
+	udpSessions sync.Map
+
+	// health holds target up/down state when health checking is enabled
+	// for this listener (config.HealthCheckInterval != ""); nil otherwise.
+	health *healthTracker
+
+	// rrCounter drives round-robin target selection.
+	rrCounter atomic.Uint64
+
+	// udpBufPool pools per-datagram read buffers so fan-out to multiple
+	// targets shares one buffer instead of copying it per goroutine.
+	// Only used by UDP listeners.
+	udpBufPool *sync.Pool
+
+	// udpBufferSize is the resolved (default-applied) buffer size backing
+	// udpBufPool, reused for reply-reader buffers so they honor the same
+	// configured bound.
+	udpBufferSize int
+
+	// truncatedPackets counts UDP datagrams that filled the read buffer,
+	// a sign that udpBufferSize is too small for the traffic received.
+	truncatedPackets atomic.Uint64
+}
+
+func newRunningListener(config ListenerConfig) *runningListener {
+	rl := &runningListener{
+		config:  config,
+		stop:    make(chan struct{}),
+		tcpPool: newTCPPool(),
+	}
+	rl.targets.Store(config.TargetServers)
+	return rl
+}
+
+// currentTargets returns the listener's current target set. It is safe to
+// call concurrently with setTargets.
+func (rl *runningListener) currentTargets() []TargetConfig {
+	return rl.targets.Load().([]TargetConfig)
+}
+
+// setTargets atomically swaps in a new target set, e.g. after a config
+// reload. In-flight connections keep using whatever target set they
+// already dialed; only new reads/connections see the update.
+func (rl *runningListener) setTargets(targets []TargetConfig) {
+	rl.targets.Store(targets)
+}
+
+// Manager owns the set of currently running listeners and reconciles them
+// against newly loaded configuration.
+type Manager struct {
+	mu        sync.Mutex
+	listeners map[string]*runningListener
+	wg        sync.WaitGroup
+}
+
+func newManager() *Manager {
+	return &Manager{listeners: make(map[string]*runningListener)}
+}
+
+// Reconcile starts listeners present in config but not yet running, stops
+// running listeners absent from config, and updates the target set of
+// listeners that are present in both (without restarting them).
+func (m *Manager) Reconcile(config *AppConfig) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	wanted := make(map[string]ListenerConfig, len(config.Listeners))
+	for _, l := range config.Listeners {
+		wanted[l.ID] = l
+	}
+
+	// Stop listeners that were removed from config.
+	for id, rl := range m.listeners {
+		if _, ok := wanted[id]; !ok {
+			log.Printf("Stopping removed listener %s", id)
+			close(rl.stop)
+			rl.tcpPool.Close()
+			delete(m.listeners, id)
+		}
+	}
+
+	// Start new listeners and update existing ones in place.
+	for id, cfg := range wanted {
+		if rl, ok := m.listeners[id]; ok {
+			rl.setTargets(cfg.TargetServers)
+			continue
+		}
+
+		rl := newRunningListener(cfg)
+		m.listeners[id] = rl
+		m.wg.Add(1)
+		go func() {
+			defer m.wg.Done()
+			runListener(rl)
+		}()
+	}
+}
+
+// Wait blocks until every managed listener has stopped.
+func (m *Manager) Wait() {
+	m.wg.Wait()
+}
+
+// runListener starts health checking (if configured) and dispatches to the
+// protocol-specific listener loop.
+func runListener(rl *runningListener) {
+	if rl.config.HealthCheckInterval != "" {
+		interval, err := time.ParseDuration(rl.config.HealthCheckInterval)
+		if err != nil || interval <= 0 {
+			log.Printf("Invalid healthCheckInterval %q for %s, using default %s", rl.config.HealthCheckInterval, rl.config.ID, defaultHealthCheckInterval)
+			interval = defaultHealthCheckInterval
+		}
+		threshold := rl.config.HealthCheckFailureThreshold
+		if threshold <= 0 {
+			threshold = defaultHealthCheckFailureThreshold
+		}
+		rl.health = newHealthTracker(rl.config.TargetServers)
+		go runHealthChecks(rl, interval, threshold)
+	}
+
+	switch rl.config.Protocol {
+	case "udp":
+		startUDPListener(rl)
+	case "tcp":
+		startTCPListener(rl)
+	default:
+		log.Printf("Unknown protocol: %s", rl.config.Protocol)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// fdPrefix marks a ListenAddr as a pre-bound file descriptor passed down by
+// a supervisor (systemd/inetd-style socket activation), e.g. "fd:3".
+const fdPrefix = "fd:"
+
+// fileFromFDSpec turns a "fd:N" address into the *os.File wrapping
+// descriptor N.
+func fileFromFDSpec(spec string) (*os.File, error) {
+	n, err := strconv.Atoi(strings.TrimPrefix(spec, fdPrefix))
+	if err != nil {
+		return nil, fmt.Errorf("invalid socket-activation address %q: %w", spec, err)
+	}
+	return os.NewFile(uintptr(n), spec), nil
+}
+
+// listenTCP opens the TCP listening socket for a listener: either a
+// pre-bound file descriptor (ListenAddr "fd:N") or a fresh net.Listen.
+func listenTCP(rl *runningListener) (net.Listener, error) {
+	if strings.HasPrefix(rl.config.ListenAddr, fdPrefix) {
+		f, err := fileFromFDSpec(rl.config.ListenAddr)
+		if err != nil {
+			return nil, err
+		}
+		return net.FileListener(f)
+	}
+	return net.Listen("tcp", rl.config.ListenAddr)
+}
+
+// listenUDP opens the UDP listening socket for a listener: a pre-bound
+// file descriptor, a multicast group join, or a plain net.ListenPacket,
+// depending on configuration.
+func listenUDP(rl *runningListener) (net.PacketConn, error) {
+	if strings.HasPrefix(rl.config.ListenAddr, fdPrefix) {
+		f, err := fileFromFDSpec(rl.config.ListenAddr)
+		if err != nil {
+			return nil, err
+		}
+		return net.FilePacketConn(f)
+	}
+
+	if rl.config.MulticastGroup != "" {
+		return listenMulticastUDP(rl)
+	}
+
+	network := rl.config.Network
+	if network == "" {
+		network = "udp"
+	}
+	return net.ListenPacket(network, rl.config.ListenAddr)
+}
+
+// listenMulticastUDP joins the configured multicast group on the
+// configured interface, listening on the port from ListenAddr.
+func listenMulticastUDP(rl *runningListener) (net.PacketConn, error) {
+	network := rl.config.Network
+	if network == "" {
+		network = "udp"
+	}
+
+	if rl.config.Interface == "" {
+		return nil, fmt.Errorf("multicast listener requires interface to be set")
+	}
+
+	_, port, err := net.SplitHostPort(rl.config.ListenAddr)
+	if err != nil {
+		return nil, fmt.Errorf("multicast listener needs a ':port' listenAddr: %w", err)
+	}
+
+	gaddr, err := net.ResolveUDPAddr(network, net.JoinHostPort(rl.config.MulticastGroup, port))
+	if err != nil {
+		return nil, fmt.Errorf("resolving multicast group %s: %w", rl.config.MulticastGroup, err)
+	}
+
+	iface, err := net.InterfaceByName(rl.config.Interface)
+	if err != nil {
+		return nil, fmt.Errorf("interface %s: %w", rl.config.Interface, err)
+	}
+
+	return net.ListenMulticastUDP(network, iface, gaddr)
+}
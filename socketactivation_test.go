@@ -0,0 +1,15 @@
+package main
+
+import "testing"
+
+func TestListenMulticastUDPRequiresInterface(t *testing.T) {
+	rl := newRunningListener(ListenerConfig{
+		ID:             "t",
+		ListenAddr:     ":0",
+		MulticastGroup: "239.0.0.1",
+	})
+
+	if _, err := listenMulticastUDP(rl); err == nil {
+		t.Fatal("expected error for multicast listener with no interface configured")
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"io"
+	"log"
+	"net"
+)
+
+// startTCPListener starts a TCP listener based on the given configuration
+// and runs until rl.stop is closed.
+func startTCPListener(rl *runningListener) {
+	listener, err := listenTCP(rl)
+	if err != nil {
+		log.Printf("Error starting TCP listener on %s: %v", rl.config.ListenAddr, err)
+		return
+	}
+	defer listener.Close()
+	log.Printf("TCP listener started on %s", rl.config.ListenAddr)
+
+	go func() {
+		<-rl.stop
+		listener.Close()
+	}()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			select {
+			case <-rl.stop:
+				return
+			default:
+			}
+			log.Printf("Error accepting TCP connection: %v", err)
+			continue
+		}
+		log.Printf("New TCP connection from %s", conn.RemoteAddr())
+
+		go handleTCPConnection(conn, rl)
+	}
+}
+
+// handleTCPConnection handles a single client connection, proxying it to
+// the listener's current target set. A single target is proxied as a raw
+// bidirectional stream; multiple targets are fanned out, using
+// length-prefixed framing to preserve message boundaries when the
+// listener is configured for it.
+func handleTCPConnection(conn net.Conn, rl *runningListener) {
+	defer conn.Close()
+
+	targets := selectTargets(rl, clientHost(conn.RemoteAddr()))
+	if len(targets) == 0 {
+		log.Printf("No target servers configured for %s, dropping connection from %s", rl.config.ListenAddr, conn.RemoteAddr())
+		return
+	}
+
+	if len(targets) == 1 {
+		proxyStream(conn, rl, targets[0])
+		return
+	}
+
+	fanOutStream(conn, rl, targets)
+}
+
+// proxyStream establishes (or reuses) one persistent upstream connection
+// and copies data in both directions until either side closes.
+func proxyStream(conn net.Conn, rl *runningListener, target TargetConfig) {
+	upstream, err := rl.tcpPool.get(target.Address)
+	if err != nil {
+		log.Printf("Error connecting to TCP server %s: %v", target.Address, err)
+		return
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(upstream, conn)
+		if tc, ok := upstream.(*net.TCPConn); ok {
+			tc.CloseWrite()
+		}
+		done <- err
+	}()
+
+	_, copyErr := io.Copy(conn, upstream)
+
+	// The server->client leg is done (upstream hit EOF or an error). If
+	// the client never closes its own side, the client->upstream copy
+	// above is still parked in conn.Read with nothing to wake it; half-
+	// close (or close) conn's read side so that goroutine returns and
+	// this handler doesn't leak.
+	if tc, ok := conn.(*net.TCPConn); ok {
+		tc.CloseRead()
+	} else {
+		conn.Close()
+	}
+
+	writeErr := <-done
+
+	if copyErr != nil || writeErr != nil {
+		upstream.Close()
+		return
+	}
+	rl.tcpPool.put(target.Address, upstream)
+}
+
+// fanOutStream forwards client data to every target. When rl.config.Framed
+// is set, the client stream is read as discrete length-prefixed messages
+// and each message is written whole to every target; otherwise raw reads
+// are copied to every target as-is. Reply traffic from targets is not
+// proxied back to the client: with more than one target there is no single
+// upstream to associate a reply with.
+func fanOutStream(conn net.Conn, rl *runningListener, targets []TargetConfig) {
+	upstreams := make(map[string]net.Conn, len(targets))
+	for _, target := range targets {
+		upstream, err := rl.tcpPool.get(target.Address)
+		if err != nil {
+			log.Printf("Error connecting to TCP server %s: %v", target.Address, err)
+			continue
+		}
+		upstreams[target.Address] = upstream
+	}
+	defer func() {
+		for addr, upstream := range upstreams {
+			rl.tcpPool.put(addr, upstream)
+		}
+	}()
+
+	if rl.config.Framed {
+		for {
+			frame, err := readFrame(conn)
+			if err != nil {
+				return
+			}
+			writeFrameToAll(upstreams, frame)
+		}
+	}
+
+	buf := make([]byte, 4096)
+	for {
+		n, err := conn.Read(buf)
+		if n > 0 {
+			writeToAll(upstreams, buf[:n])
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// writeFrameToAll writes frame to every upstream, closing and evicting from
+// upstreams any that error rather than leaving them for the caller to pool
+// back: a write error means the connection is no longer usable, and
+// tcpPool.put requires callers to close such connections instead.
+func writeFrameToAll(upstreams map[string]net.Conn, frame []byte) {
+	for addr, upstream := range upstreams {
+		if err := writeFrame(upstream, frame); err != nil {
+			log.Printf("Error forwarding framed TCP data to %s: %v", addr, err)
+			upstream.Close()
+			delete(upstreams, addr)
+		}
+	}
+}
+
+// writeToAll writes data to every upstream, closing and evicting from
+// upstreams any that error (see writeFrameToAll).
+func writeToAll(upstreams map[string]net.Conn, data []byte) {
+	for addr, upstream := range upstreams {
+		if _, err := upstream.Write(data); err != nil {
+			log.Printf("Error forwarding TCP data to %s: %v", addr, err)
+			upstream.Close()
+			delete(upstreams, addr)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"io"
+	"net"
+	"testing"
+)
+
+// acceptAndClose starts a listener that closes every accepted connection
+// immediately, simulating an upstream that's gone away. It waits for the
+// accept to complete before returning, so the listener can be closed right
+// away without racing a not-yet-accepted connection into a reset.
+func acceptAndClose(t *testing.T) net.Conn {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	accepted := make(chan struct{})
+	go func() {
+		defer close(accepted)
+		conn, err := ln.Accept()
+		if err == nil {
+			conn.Close()
+		}
+	}()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-accepted
+	ln.Close()
+	conn.Close() // force the peer (our write side below) to see an error
+	return conn
+}
+
+// acceptAndDiscard starts a listener that reads and discards everything
+// sent to it, simulating a healthy upstream. It waits for the accept to
+// complete before returning, for the same reason as acceptAndClose.
+func acceptAndDiscard(t *testing.T) net.Conn {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	accepted := make(chan struct{})
+	go func() {
+		conn, err := ln.Accept()
+		close(accepted)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		io.Copy(io.Discard, conn)
+	}()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-accepted
+	ln.Close()
+	return conn
+}
+
+func TestWriteToAllEvictsBrokenUpstream(t *testing.T) {
+	good := acceptAndDiscard(t)
+	defer good.Close()
+	bad := acceptAndClose(t)
+
+	upstreams := map[string]net.Conn{"good": good, "bad": bad}
+
+	// The closed connection may need a write or two before the OS reports
+	// the broken pipe/reset; retry a handful of times.
+	for i := 0; i < 5 && len(upstreams) == 2; i++ {
+		writeToAll(upstreams, []byte("ping"))
+	}
+
+	if _, ok := upstreams["bad"]; ok {
+		t.Fatal("writeToAll left a broken upstream in the map instead of evicting it")
+	}
+	if _, ok := upstreams["good"]; !ok {
+		t.Fatal("writeToAll evicted the healthy upstream")
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+// TestProxyStreamReturnsWhenServerClosesFirst reproduces a session where
+// the upstream target closes its side while the client leaves its
+// connection open and idle. proxyStream must still return (and thus let
+// handleTCPConnection's deferred conn.Close() run) instead of leaking the
+// goroutine copying client->upstream forever.
+func TestProxyStreamReturnsWhenServerClosesFirst(t *testing.T) {
+	upstreamListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer upstreamListener.Close()
+
+	go func() {
+		conn, err := upstreamListener.Accept()
+		if err != nil {
+			return
+		}
+		conn.Close()
+	}()
+
+	// A second loopback listener just to obtain a real *net.TCPConn to
+	// stand in for the client side, which we deliberately never close.
+	clientListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer clientListener.Close()
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, err := clientListener.Accept()
+		if err == nil {
+			accepted <- conn
+		}
+	}()
+
+	clientSide, err := net.Dial("tcp", clientListener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer clientSide.Close()
+
+	serverSide := <-accepted
+	defer serverSide.Close()
+
+	rl := newRunningListener(ListenerConfig{ID: "test"})
+	target := TargetConfig{Address: upstreamListener.Addr().String()}
+
+	returned := make(chan struct{})
+	go func() {
+		proxyStream(serverSide, rl, target)
+		close(returned)
+	}()
+
+	select {
+	case <-returned:
+	case <-time.After(2 * time.Second):
+		t.Fatal("proxyStream did not return after upstream closed while client stayed open")
+	}
+}
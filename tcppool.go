@@ -0,0 +1,61 @@
+package main
+
+import (
+	"net"
+	"sync"
+)
+
+// tcpPool is a small per-listener pool of idle upstream TCP connections,
+// keyed by target address. It lets handleTCPConnection reuse an existing
+// connection to a target instead of dialing a fresh one for every proxied
+// session.
+type tcpPool struct {
+	mu   sync.Mutex
+	idle map[string][]net.Conn
+}
+
+func newTCPPool() *tcpPool {
+	return &tcpPool{idle: make(map[string][]net.Conn)}
+}
+
+// get returns an idle connection to address if one is available, otherwise
+// it dials a new one via the Dialer that address's scheme selects (plain
+// TCP, TLS, SOCKS5, or HTTP CONNECT).
+func (p *tcpPool) get(address string) (net.Conn, error) {
+	p.mu.Lock()
+	if conns := p.idle[address]; len(conns) > 0 {
+		conn := conns[len(conns)-1]
+		p.idle[address] = conns[:len(conns)-1]
+		p.mu.Unlock()
+		return conn, nil
+	}
+	p.mu.Unlock()
+
+	dialer, err := newDialer(address)
+	if err != nil {
+		return nil, err
+	}
+	return dialer.Dial()
+}
+
+// put returns a still-usable connection to the pool for reuse by a future
+// session. Callers that detect an error on conn should close it instead.
+func (p *tcpPool) put(address string, conn net.Conn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.idle[address] = append(p.idle[address], conn)
+}
+
+// Close closes every idle connection held by the pool and discards them.
+// Callers should call this once the pool's listener is torn down, otherwise
+// its idle connections are never closed.
+func (p *tcpPool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for address, conns := range p.idle {
+		for _, conn := range conns {
+			conn.Close()
+		}
+		delete(p.idle, address)
+	}
+}
@@ -0,0 +1,19 @@
+package main
+
+import "testing"
+
+func TestTCPPoolCloseClosesIdleConns(t *testing.T) {
+	pool := newTCPPool()
+
+	good := acceptAndDiscard(t)
+	pool.put("target-a", good)
+
+	pool.Close()
+
+	if _, err := good.Write([]byte("x")); err == nil {
+		t.Fatal("Close left a pooled connection open")
+	}
+	if len(pool.idle) != 0 {
+		t.Fatalf("Close left %d address(es) in the idle map, want 0", len(pool.idle))
+	}
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultUDPIdleTimeout is how long an idle UDP session is kept alive when
+// the listener doesn't configure one explicitly.
+const defaultUDPIdleTimeout = 30 * time.Second
+
+// defaultUDPBufferSize is the read buffer size used when a listener
+// doesn't configure udpBufferSize; it's large enough for the biggest
+// possible UDP payload.
+const defaultUDPBufferSize = 65535
+
+// udpSession tracks the per-target upstream sockets opened on behalf of a
+// single client 4-tuple, so that target replies can be piped back to that
+// client and so that a second packet from the same client reuses the same
+// upstream sockets instead of opening new ones.
+type udpSession struct {
+	clientAddr net.Addr
+
+	mu        sync.Mutex
+	upstreams map[string]*net.UDPConn
+
+	lastActive atomic.Int64 // unix nano
+}
+
+func (s *udpSession) touch() {
+	s.lastActive.Store(time.Now().UnixNano())
+}
+
+func (s *udpSession) idleSince() time.Duration {
+	return time.Since(time.Unix(0, s.lastActive.Load()))
+}
+
+func (s *udpSession) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, upstream := range s.upstreams {
+		upstream.Close()
+	}
+}
+
+// startUDPListener starts a UDP listener based on the given configuration
+// and runs until rl.stop is closed. Replies from target servers are piped
+// back to the originating client via a per-client conntrack session.
+func startUDPListener(rl *runningListener) {
+	conn, err := listenUDP(rl)
+	if err != nil {
+		log.Printf("Error starting UDP listener on %s: %v", rl.config.ListenAddr, err)
+		return
+	}
+	defer conn.Close()
+	log.Printf("UDP listener started on %s", rl.config.ListenAddr)
+
+	idleTimeout := parseUDPIdleTimeout(rl.config.UDPIdleTimeout)
+	bufSize := rl.config.UDPBufferSize
+	if bufSize <= 0 {
+		bufSize = defaultUDPBufferSize
+	}
+	rl.udpBufferSize = bufSize
+	rl.udpBufPool = &sync.Pool{
+		New: func() any {
+			b := make([]byte, bufSize)
+			return &b
+		},
+	}
+
+	go func() {
+		<-rl.stop
+		conn.Close()
+	}()
+	go reapIdleUDPSessions(rl, idleTimeout)
+
+	for {
+		bufPtr := rl.udpBufPool.Get().(*[]byte)
+		buf := *bufPtr
+
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			rl.udpBufPool.Put(bufPtr)
+			select {
+			case <-rl.stop:
+				return
+			default:
+			}
+			log.Printf("Error reading UDP packet: %v", err)
+			continue
+		}
+		log.Printf("Received UDP packet from %s", addr)
+
+		if n == len(buf) {
+			rl.truncatedPackets.Add(1)
+			log.Printf("UDP packet from %s filled the %d-byte read buffer and may have been truncated; consider raising udpBufferSize", addr, len(buf))
+		}
+
+		targets := selectTargets(rl, clientHost(addr))
+		if len(targets) == 0 {
+			rl.udpBufPool.Put(bufPtr)
+			continue
+		}
+
+		session := getOrCreateUDPSession(rl, addr)
+		session.touch()
+
+		pkt := &udpPacket{bufPtr: bufPtr, data: buf[:n], pool: rl.udpBufPool}
+		pkt.pending.Store(int32(len(targets)))
+		for _, target := range targets {
+			go func(target TargetConfig) {
+				defer pkt.release()
+				forwardUDPToTarget(rl, conn, session, target, pkt.data)
+			}(target)
+		}
+	}
+}
+
+// udpPacket is a single received datagram shared read-only across every
+// target it's fanned out to. Its buffer is returned to the pool once every
+// goroutine forwarding it has finished, avoiding a per-target copy.
+type udpPacket struct {
+	bufPtr  *[]byte
+	data    []byte
+	pool    *sync.Pool
+	pending atomic.Int32
+}
+
+func (p *udpPacket) release() {
+	if p.pending.Add(-1) == 0 {
+		p.pool.Put(p.bufPtr)
+	}
+}
+
+// getOrCreateUDPSession returns the existing session for addr, or creates
+// and registers a new one.
+func getOrCreateUDPSession(rl *runningListener, addr net.Addr) *udpSession {
+	key := addr.String()
+	if v, ok := rl.udpSessions.Load(key); ok {
+		return v.(*udpSession)
+	}
+
+	session := &udpSession{clientAddr: addr, upstreams: make(map[string]*net.UDPConn)}
+	actual, loaded := rl.udpSessions.LoadOrStore(key, session)
+	if loaded {
+		return actual.(*udpSession)
+	}
+	return session
+}
+
+// forwardUDPToTarget writes data to the session's upstream socket for
+// target, dialing one and starting its reply-reader goroutine if this is
+// the session's first packet to that target. UDP targets are always
+// dialed directly: the Dialer abstraction (direct/TLS/SOCKS5/CONNECT) only
+// applies to TCP forwarding.
+func forwardUDPToTarget(rl *runningListener, listenerConn net.PacketConn, session *udpSession, target TargetConfig, data []byte) {
+	if strings.Contains(target.Address, "://") {
+		log.Printf("Target %s uses a dialer scheme, which UDP listeners don't support; dropping packet", target.Address)
+		return
+	}
+
+	session.mu.Lock()
+	upstream, ok := session.upstreams[target.Address]
+	if !ok {
+		targetAddr, err := net.ResolveUDPAddr("udp", target.Address)
+		if err != nil {
+			session.mu.Unlock()
+			log.Printf("Error resolving UDP server %s: %v", target.Address, err)
+			return
+		}
+		upstream, err = net.DialUDP("udp", nil, targetAddr)
+		if err != nil {
+			session.mu.Unlock()
+			log.Printf("Error dialing UDP server %s: %v", target.Address, err)
+			return
+		}
+		session.upstreams[target.Address] = upstream
+		go pipeUDPReplies(rl, listenerConn, session, target.Address, upstream)
+	}
+	session.mu.Unlock()
+
+	if _, err := upstream.Write(data); err != nil {
+		log.Printf("Error forwarding UDP to %s: %v", target.Address, err)
+		return
+	}
+	log.Printf("Forwarded UDP packet to %s", target.Address)
+}
+
+// pipeUDPReplies reads datagrams from upstream and writes them back to the
+// session's client through the shared listener socket, until upstream is
+// closed (by idle eviction or listener shutdown).
+func pipeUDPReplies(rl *runningListener, listenerConn net.PacketConn, session *udpSession, targetAddr string, upstream *net.UDPConn) {
+	buf := make([]byte, rl.udpBufferSize)
+	for {
+		n, err := upstream.Read(buf)
+		if err != nil {
+			return
+		}
+		session.touch()
+
+		if _, err := listenerConn.WriteTo(buf[:n], session.clientAddr); err != nil {
+			log.Printf("Error writing UDP reply from %s to %s: %v", targetAddr, session.clientAddr, err)
+			return
+		}
+	}
+}
+
+// reapIdleUDPSessions periodically evicts sessions that have seen no
+// traffic for longer than idleTimeout, closing their upstream sockets.
+func reapIdleUDPSessions(rl *runningListener, idleTimeout time.Duration) {
+	ticker := time.NewTicker(idleTimeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-rl.stop:
+			rl.udpSessions.Range(func(key, value any) bool {
+				value.(*udpSession).close()
+				rl.udpSessions.Delete(key)
+				return true
+			})
+			return
+		case <-ticker.C:
+			rl.udpSessions.Range(func(key, value any) bool {
+				session := value.(*udpSession)
+				if session.idleSince() >= idleTimeout {
+					session.close()
+					rl.udpSessions.Delete(key)
+				}
+				return true
+			})
+		}
+	}
+}
+
+func parseUDPIdleTimeout(configured string) time.Duration {
+	if configured == "" {
+		return defaultUDPIdleTimeout
+	}
+	d, err := time.ParseDuration(configured)
+	if err != nil || d <= 0 {
+		log.Printf("Invalid udpIdleTimeout %q, using default %s", configured, defaultUDPIdleTimeout)
+		return defaultUDPIdleTimeout
+	}
+	return d
+}
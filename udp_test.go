@@ -0,0 +1,29 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseUDPIdleTimeout(t *testing.T) {
+	tests := []struct {
+		name       string
+		configured string
+		want       time.Duration
+	}{
+		{name: "empty uses default", configured: "", want: defaultUDPIdleTimeout},
+		{name: "valid duration", configured: "45s", want: 45 * time.Second},
+		{name: "garbage falls back to default", configured: "not-a-duration", want: defaultUDPIdleTimeout},
+		{name: "zero falls back to default", configured: "0s", want: defaultUDPIdleTimeout},
+		{name: "negative falls back to default", configured: "-5s", want: defaultUDPIdleTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseUDPIdleTimeout(tt.configured)
+			if got != tt.want {
+				t.Errorf("parseUDPIdleTimeout(%q) = %v, want %v", tt.configured, got, tt.want)
+			}
+		})
+	}
+}